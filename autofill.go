@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/codegangsta/cli"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+	"strconv"
+)
+
+const rippledURL = "wss://s-east.ripple.com:443"
+
+// remote is the websocket connection, opened on first use by dialRemote so
+// --autofill and --submit share a single connection instead of each dialing
+// their own.
+var remote *websockets.Remote
+
+func dialRemote() *websockets.Remote {
+	if remote != nil {
+		return remote
+	}
+	r, err := websockets.NewRemote(rippledURL)
+	checkErr(err)
+	go r.Run()
+	remote = r
+	return remote
+}
+
+type autofillResult struct {
+	sequence           uint32
+	fee                int64
+	lastLedgerSequence uint32
+}
+
+var autofilled *autofillResult
+
+// autofill queries the network for account's next Sequence, the current
+// load-adjusted fee, and the latest ledger index, and stashes the result in
+// autofilled for fillBase to use instead of the --sequence/--fee/--lastledger
+// flags.
+func autofill(c *cli.Context, account *data.Account) {
+	r := dialRemote()
+
+	info, err := r.AccountInfo(*account)
+	checkErr(err)
+
+	fee, err := r.Fee()
+	checkErr(err)
+	drops, err := strconv.ParseInt(fee.Drops.OpenLedgerFee, 10, 64)
+	checkErr(err)
+
+	autofilled = &autofillResult{
+		sequence:           info.AccountData.Sequence,
+		fee:                drops,
+		lastLedgerSequence: info.LedgerSequence + uint32(c.GlobalInt("autofill-buffer")),
+	}
+}
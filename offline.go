@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"github.com/rubblelabs/ripple/data"
+	"os"
+)
+
+var offlineCommands = []cli.Command{
+	{
+		Name:        "sign-tx",
+		Usage:       "sign an unsigned transaction produced with --build-tx",
+		Description: "seed is required; the unsigned transaction is read from --tx or stdin",
+		Action:      signTx,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "tx", Value: "", Usage: "file containing the unsigned transaction (JSON or binary), or - / omitted for stdin"},
+		},
+	},
+	{
+		Name:        "submit-tx",
+		Usage:       "submit a pre-signed transaction",
+		Description: "the signed transaction is read from --tx or stdin",
+		Action:      submitTxCmd,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "tx", Value: "", Usage: "file containing the signed transaction (JSON or binary), or - / omitted for stdin"},
+		},
+	},
+}
+
+// decodeTxBytes decodes raw as a transaction, accepting either the JSON form
+// produced by --json or the binary form produced by --binary, so a tx can
+// cross the stdin/stdout boundary between hosts in whichever form is handy.
+func decodeTxBytes(raw []byte) data.Transaction {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return decodeTx(trimmed)
+	}
+	tx, err := data.ReadTransaction(bytes.NewReader(trimmed))
+	checkErr(err)
+	return tx
+}
+
+func signTx(c *cli.Context) {
+	if key == nil {
+		fmt.Println("Seed is required")
+		os.Exit(1)
+	}
+	tx := decodeTxBytes(readInput(c.String("tx")))
+
+	priv, err := key.GenerateAccountKey(accountIndex(c))
+	checkErr(err)
+	id, err := key.GenerateAccountId(accountIndex(c))
+	checkErr(err)
+	finalizeSign(tx, priv, id)
+
+	outputTx(c, tx)
+}
+
+func submitTxCmd(c *cli.Context) {
+	submitTx(c, decodeTxBytes(readInput(c.String("tx"))))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+	"os"
+	"strings"
+	"time"
+)
+
+// submitTx submits tx, honoring --retry (resubmit on transient engine
+// results or network errors, backing off exponentially) and --wait (poll
+// the tx command until it's validated). A tesSUCCESS preliminary result
+// only means the transaction was relayed, not that it was validated, so
+// --wait is the only way to be sure of the outcome. Exits 0 on a tes
+// preliminary result, or with --wait once the transaction is validated (the
+// final metadata result is printed, not checked); retries exhausted, a
+// non-tes preliminary result, a LastLedgerSequence timeout, or a --wait
+// timeout all exit nonzero so calling scripts can tell.
+func submitTx(c *cli.Context, tx data.Transaction) {
+	deadline := time.Now().Add(c.GlobalDuration("timeout"))
+
+	result, err := dialRemote().Submit(tx)
+	backoff := 500 * time.Millisecond
+	for c.GlobalBool("retry") && retriable(result, err) && time.Now().Before(deadline) && !lastLedgerPassed(tx) {
+		time.Sleep(backoff)
+		result, err = dialRemote().Submit(tx)
+		if backoff < 10*time.Second {
+			backoff *= 2
+		}
+	}
+	checkErr(err)
+	fmt.Printf("%s: %s\n", result.EngineResult, result.EngineResultMessage)
+
+	if c.GlobalBool("retry") && retriable(result, nil) {
+		fmt.Println("gave up retrying: LastLedgerSequence passed or --timeout elapsed without a final engine result")
+		os.Exit(1)
+	}
+	if !strings.HasPrefix(string(result.EngineResult), "tes") {
+		os.Exit(1)
+	}
+
+	if !c.GlobalBool("wait") {
+		os.Exit(0)
+	}
+	if !waitForValidation(tx, deadline) {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// retriable reports whether a submission is worth resubmitting: a network
+// error, or a tef/ter engine result (malformed-for-this-ledger or requires
+// a retry, as opposed to a final tes/tec outcome).
+func retriable(result *websockets.SubmitResult, err error) bool {
+	if err != nil {
+		return true
+	}
+	code := string(result.EngineResult)
+	return strings.HasPrefix(code, "tef") || strings.HasPrefix(code, "ter")
+}
+
+// lastLedgerPassed reports whether tx's LastLedgerSequence has already been
+// closed, meaning it can no longer be included in a ledger and further
+// retries or waiting are pointless.
+func lastLedgerPassed(tx data.Transaction) bool {
+	base := tx.GetBase()
+	if base.LastLedgerSequence == nil {
+		return false
+	}
+	ledger, err := dialRemote().Ledger(nil, false)
+	if err != nil {
+		return false
+	}
+	return ledger.Ledger.LedgerSequence > *base.LastLedgerSequence
+}
+
+// waitForValidation polls the tx command by hash until the transaction
+// appears in a validated ledger, its LastLedgerSequence passes, or deadline
+// is reached, printing the final metadata result and reporting whether the
+// transaction actually validated.
+func waitForValidation(tx data.Transaction, deadline time.Time) bool {
+	hash, _, err := data.Raw(tx)
+	checkErr(err)
+
+	for time.Now().Before(deadline) {
+		result, err := dialRemote().Tx(hash)
+		if err == nil && result.Validated {
+			out, err := json.Marshal(result.MetaData)
+			checkErr(err)
+			fmt.Println(string(out))
+			return true
+		}
+		if lastLedgerPassed(tx) {
+			fmt.Println("LastLedgerSequence exceeded without validation")
+			return false
+		}
+		time.Sleep(2 * time.Second)
+	}
+	fmt.Println("timed out waiting for validation")
+	return false
+}
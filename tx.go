@@ -6,9 +6,9 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/rubblelabs/ripple/crypto"
 	"github.com/rubblelabs/ripple/data"
-	"github.com/rubblelabs/ripple/websockets"
 	"os"
 	"strings"
+	"time"
 )
 
 func checkErr(err error) {
@@ -18,14 +18,6 @@ func checkErr(err error) {
 	}
 }
 
-func parseSeed(s string) *crypto.RootDeterministicKey {
-	seed, err := crypto.NewRippleHashCheck(s, crypto.RIPPLE_FAMILY_SEED)
-	checkErr(err)
-	key, err := crypto.GenerateRootDeterministicKey(seed.Payload())
-	checkErr(err)
-	return key
-}
-
 func parseAccount(s string) *data.Account {
 	account, err := data.NewAccountFromAddress(s)
 	checkErr(err)
@@ -48,47 +40,129 @@ func parsePaths(s string) *data.PathSet {
 	return &ps
 }
 
+// noKey reports whether a subcommand is missing the seed it needs to sign,
+// i.e. no seed was given and this isn't a --build-tx invocation (which
+// intentionally leaves key nil, since the transaction isn't signed yet).
+func noKey(c *cli.Context) bool {
+	return key == nil && !c.GlobalBool("build-tx")
+}
+
 func sign(c *cli.Context, tx data.Transaction, sequence int32) {
+	base := tx.GetBase()
+	fillBase(c, base)
+
+	if c.GlobalBool("build-tx") {
+		account := parseAccount(c.GlobalString("account"))
+		copy(base.Account[:], account[:])
+		return
+	}
+
 	priv, err := key.GenerateAccountKey(sequence)
 	checkErr(err)
 	id, err := key.GenerateAccountId(sequence)
 	checkErr(err)
-	pub, err := priv.PublicAccountKey()
-	checkErr(err)
-	base := tx.GetBase()
+
+	if c.GlobalBool("multisign") {
+		account := parseAccount(c.GlobalString("account"))
+		copy(base.Account[:], account[:])
+		base.SigningPubKey = new(data.PublicKey)
+		signer := multisignEntry(tx, priv, id)
+		base.Signers = append(base.Signers, data.SignerItem{Signer: *signer})
+		return
+	}
+
+	finalizeSign(tx, priv, id)
+}
+
+// fillBase sets the fields common to every transaction (Sequence, Fee,
+// LastLedgerSequence, Flags) from the global flags, independent of whether
+// the transaction is about to be signed or only built for later signing.
+func fillBase(c *cli.Context, base *data.TxBase) {
+	if base.Flags == nil {
+		base.Flags = new(data.TransactionFlag)
+	}
+
+	if autofilled != nil {
+		base.Sequence = autofilled.sequence
+		base.LastLedgerSequence = new(uint32)
+		*base.LastLedgerSequence = autofilled.lastLedgerSequence
+		fee, err := data.NewNativeValue(autofilled.fee)
+		checkErr(err)
+		base.Fee = *fee
+		return
+	}
+
 	base.Sequence = uint32(c.GlobalInt("sequence"))
-	base.SigningPubKey = new(data.PublicKey)
 	if c.GlobalInt("lastledger") > 0 {
 		base.LastLedgerSequence = new(uint32)
 		*base.LastLedgerSequence = uint32(c.GlobalInt("lastledger"))
 	}
-	if base.Flags == nil {
-		base.Flags = new(data.TransactionFlag)
-	}
-	copy(base.Account[:], id.Payload())
-	copy(base.SigningPubKey[:], pub.Payload())
 	if c.GlobalString("fee") != "" {
 		fee, err := data.NewNativeValue(int64(c.GlobalInt("fee")))
 		checkErr(err)
 		base.Fee = *fee
 	}
-	tx.GetBase().TxnSignature = &data.VariableLength{}
+}
+
+// finalizeSign signs tx as the account derived from id/priv, the normal
+// single-signer path shared by every transaction type and by sign-tx.
+func finalizeSign(tx data.Transaction, priv *crypto.AccountPrivateKey, id *crypto.AccountId) {
+	pub, err := priv.PublicAccountKey()
+	checkErr(err)
+	base := tx.GetBase()
+	base.SigningPubKey = new(data.PublicKey)
+	copy(base.Account[:], id.Payload())
+	copy(base.SigningPubKey[:], pub.Payload())
+	base.TxnSignature = &data.VariableLength{}
 	checkErr(data.Sign(tx, priv))
 }
 
-func submitTx(tx data.Transaction) {
-	r, err := websockets.NewRemote("wss://s-east.ripple.com:443")
+// multisignEntry signs tx on behalf of the account derived from id, returning
+// its contribution to the transaction's Signers array rather than mutating
+// the transaction's own SigningPubKey/TxnSignature.
+func multisignEntry(tx data.Transaction, priv *crypto.AccountPrivateKey, id *crypto.AccountId) *data.Signer {
+	pub, err := priv.PublicAccountKey()
 	checkErr(err)
-	go r.Run()
-	result, err := r.Submit(tx)
+	signer := &data.Signer{
+		SigningPubKey: new(data.PublicKey),
+	}
+	copy(signer.Account[:], id.Payload())
+	copy(signer.SigningPubKey[:], pub.Payload())
+	checkErr(data.MultiSign(tx, priv, &signer.Account))
+	signer.TxnSignature = *tx.GetBase().TxnSignature
+	tx.GetBase().TxnSignature = nil
+	return signer
+}
+
+// outputTx prints the hash/raw/JSON representation of tx according to the
+// --json/--binary flags and submits it when --submit is set. Shared by every
+// subcommand that builds and signs a transaction.
+func outputTx(c *cli.Context, tx data.Transaction) {
+	hash, raw, err := data.Raw(tx)
 	checkErr(err)
-	fmt.Printf("%s: %s\n", result.EngineResult, result.EngineResultMessage)
-	os.Exit(0)
+
+	if !c.GlobalBool("json") && !c.GlobalBool("binary") {
+		fmt.Printf("Hash: %X\nRaw: %X\n", hash, raw)
+	}
+
+	if c.GlobalBool("json") || !c.GlobalBool("binary") {
+		out, err := json.Marshal(tx)
+		checkErr(err)
+		fmt.Println(string(out))
+	}
+
+	if c.GlobalBool("binary") {
+		os.Stdout.Write(raw)
+	}
+
+	if c.GlobalBool("submit") {
+		submitTx(c, tx)
+	}
 }
 
 func payment(c *cli.Context) {
 	// Validate and parse required fields
-	if c.String("dest") == "" || c.String("amount") == "" || key == nil {
+	if c.String("dest") == "" || c.String("amount") == "" || noKey(c) {
 		fmt.Println("Destination, amount, and seed are required")
 		os.Exit(1)
 	}
@@ -120,44 +194,67 @@ func payment(c *cli.Context) {
 		*payment.Flags = *payment.Flags | data.TxLimitQuality
 	}
 
-	sign(c, payment, 0)
-	hash, raw, err := data.Raw(payment)
-	checkErr(err)
+	sign(c, payment, accountIndex(c))
+	outputTx(c, payment)
+}
 
-	if !c.GlobalBool("json") && !c.GlobalBool("binary") {
-		fmt.Printf("Hash: %X\nRaw: %X\n", hash, raw)
+func common(c *cli.Context) error {
+	switch c.Args().First() {
+	case "multisign-combine", "submit-tx":
+		return nil
+	case "sign-tx", "multisign-add":
+		if c.GlobalString("seed") == "" {
+			cli.ShowAppHelp(c)
+			os.Exit(1)
+		}
+		key = parseSeed(c, c.String("seed"))
+		return nil
 	}
-
-	if c.GlobalBool("json") || !c.GlobalBool("binary") {
-		// Print it in JSON
-		out, err := json.Marshal(payment)
-		checkErr(err)
-		fmt.Println(string(out))
+	if c.GlobalInt("sequence") == 0 && !c.GlobalBool("autofill") {
+		cli.ShowAppHelp(c)
+		os.Exit(1)
 	}
-
-	if c.GlobalBool("binary") {
-		os.Stdout.Write(raw)
+	if (c.GlobalBool("multisign") || c.GlobalBool("build-tx")) && c.GlobalString("account") == "" {
+		fmt.Println("--account is required with --multisign or --build-tx")
+		os.Exit(1)
+	}
+	if !c.GlobalBool("build-tx") {
+		if c.GlobalString("seed") == "" {
+			cli.ShowAppHelp(c)
+			os.Exit(1)
+		}
+		key = parseSeed(c, c.String("seed"))
 	}
 
-	if c.GlobalBool("submit") {
-		submitTx(payment)
+	if c.GlobalBool("autofill") {
+		account := autofillAccount(c)
+		if account == nil {
+			fmt.Println("--autofill requires --seed or --account")
+			os.Exit(1)
+		}
+		autofill(c, account)
 	}
+	return nil
 }
 
-func common(c *cli.Context) error {
-	if c.GlobalString("seed") == "" {
-		cli.ShowAppHelp(c)
-		os.Exit(1)
+// autofillAccount picks the account --autofill should query: the explicit
+// --account when given (build-tx/multisign, where the seed need not match
+// the transaction's account), otherwise the account derived from the seed.
+func autofillAccount(c *cli.Context) *data.Account {
+	if c.GlobalString("account") != "" {
+		return parseAccount(c.GlobalString("account"))
 	}
-	if c.GlobalInt("sequence") == 0 {
-		cli.ShowAppHelp(c)
-		os.Exit(1)
+	if key == nil {
+		return nil
 	}
-	key = parseSeed(c.String("seed"))
-	return nil
+	id, err := key.GenerateAccountId(accountIndex(c))
+	checkErr(err)
+	account := &data.Account{}
+	copy(account[:], id.Payload())
+	return account
 }
 
-var key *crypto.RootDeterministicKey
+var key rootKey
 
 func main() {
 	app := cli.NewApp()
@@ -172,9 +269,19 @@ func main() {
 		cli.BoolFlag{Name: "submit,t", Usage: "submits the transaction via websocket"},
 		cli.BoolFlag{Name: "binary,b", Usage: "raw output in binary"},
 		cli.BoolFlag{Name: "json,j", Usage: "output only the resulting JSON"},
+		cli.BoolFlag{Name: "multisign", Usage: "sign as a contribution to a multisigned transaction instead of submitting a TxnSignature"},
+		cli.StringFlag{Name: "account", Value: "", Usage: "the account the transaction is for (required with --multisign or --build-tx, since no seed supplies it)"},
+		cli.BoolFlag{Name: "build-tx", Usage: "emit an unsigned transaction instead of signing, for signing later with sign-tx on an air-gapped host"},
+		cli.BoolFlag{Name: "autofill", Usage: "fetch sequence, fee and lastledger from the network instead of requiring them on the command line"},
+		cli.IntFlag{Name: "autofill-buffer", Value: 20, Usage: "ledgers beyond the current one that an --autofill transaction remains valid for"},
+		cli.BoolFlag{Name: "wait", Usage: "after submitting, poll for the final validated result instead of exiting on the preliminary one"},
+		cli.BoolFlag{Name: "retry", Usage: "resubmit on transient tef/ter engine results or network errors, until LastLedgerSequence passes or --timeout elapses"},
+		cli.DurationFlag{Name: "timeout", Value: 20 * time.Second, Usage: "how long --retry and --wait keep polling before giving up"},
+		cli.StringFlag{Name: "keytype", Value: "", Usage: "key algorithm to derive from the seed: secp256k1 or ed25519 (default: detected from the seed, or secp256k1 for a mnemonic)"},
+		cli.IntFlag{Name: "account-index", Value: 0, Usage: "which account to derive from the seed"},
 	}
 	app.Before = common
-	app.Commands = []cli.Command{{
+	app.Commands = append([]cli.Command{{
 		Name:        "payment",
 		ShortName:   "p",
 		Usage:       "create a payment",
@@ -191,6 +298,6 @@ func main() {
 			cli.BoolFlag{Name: "partial,p", Usage: "permit partial payment"},
 			cli.BoolFlag{Name: "limit,l", Usage: "limit quality"},
 		},
-	}}
+	}}, append(append(txTypeCommands, multisignCommands...), offlineCommands...)...)
 	app.Run(os.Args)
 }
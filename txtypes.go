@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"github.com/codegangsta/cli"
+	"github.com/rubblelabs/ripple/data"
+	"os"
+	"strings"
+)
+
+var txTypeCommands = []cli.Command{
+	{
+		Name:        "trust",
+		ShortName:   "tr",
+		Usage:       "create a TrustSet",
+		Description: "seed, sequence and limit are required",
+		Action:      trust,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "limit,m", Value: "", Usage: "trust limit, e.g. 100/USD/rIssuer..."},
+			cli.BoolFlag{Name: "noripple", Usage: "disallow rippling through this trust line"},
+			cli.BoolFlag{Name: "clearnoripple", Usage: "allow rippling through this trust line"},
+			cli.BoolFlag{Name: "freeze", Usage: "freeze this trust line"},
+			cli.BoolFlag{Name: "clearfreeze", Usage: "unfreeze this trust line"},
+		},
+	},
+	{
+		Name:        "offer",
+		ShortName:   "o",
+		Usage:       "create an OfferCreate",
+		Description: "seed, sequence, takergets and takerpays are required",
+		Action:      offer,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "takergets,g", Value: "", Usage: "amount the offer creator pays"},
+			cli.StringFlag{Name: "takerpays,p", Value: "", Usage: "amount the offer creator receives"},
+			cli.IntFlag{Name: "expiration,e", Value: 0, Usage: "ripple epoch time after which the offer expires"},
+			cli.IntFlag{Name: "offersequence", Value: 0, Usage: "sequence of an existing offer by this account to replace"},
+			cli.BoolFlag{Name: "passive", Usage: "do not consume an offer that exactly matches"},
+			cli.BoolFlag{Name: "ioc", Usage: "immediate or cancel: only fill what can be filled immediately"},
+			cli.BoolFlag{Name: "fok", Usage: "fill or kill: fill completely and immediately, or not at all"},
+			cli.BoolFlag{Name: "sell", Usage: "sell the full takergets amount instead of stopping once takerpays is satisfied"},
+		},
+	},
+	{
+		Name:        "offercancel",
+		ShortName:   "oc",
+		Usage:       "create an OfferCancel",
+		Description: "seed, sequence and offersequence are required",
+		Action:      offerCancel,
+		Flags: []cli.Flag{
+			cli.IntFlag{Name: "offersequence", Value: 0, Usage: "sequence of the offer to cancel"},
+		},
+	},
+	{
+		Name:        "accountset",
+		ShortName:   "as",
+		Usage:       "create an AccountSet",
+		Description: "seed and sequence are required; at least one of set/clear/domain/transferrate/emailhash must be given",
+		Action:      accountSet,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "set", Value: "", Usage: "account flag to set: requiredest, requireauth, disallowxrp or defaultripple"},
+			cli.StringFlag{Name: "clear", Value: "", Usage: "account flag to clear: requiredest, requireauth, disallowxrp or defaultripple"},
+			cli.StringFlag{Name: "domain", Value: "", Usage: "domain associated with the account"},
+			cli.IntFlag{Name: "transferrate", Value: 0, Usage: "fee, in billionths, charged when users transfer this account's issuances"},
+			cli.StringFlag{Name: "emailhash", Value: "", Usage: "hex-encoded MD5 hash of an email address, for Gravatar"},
+		},
+	},
+}
+
+var accountSetFlags = map[string]uint32{
+	"requiredest":   1,
+	"requireauth":   2,
+	"disallowxrp":   3,
+	"defaultripple": 8,
+}
+
+func accountSetFlagValue(name string) uint32 {
+	flag, ok := accountSetFlags[strings.ToLower(name)]
+	if !ok {
+		fmt.Printf("unknown AccountSet flag: %s\n", name)
+		os.Exit(1)
+	}
+	return flag
+}
+
+func parseHash128(s string) *data.Hash128 {
+	hash, err := data.NewHash128(s)
+	checkErr(err)
+	return hash
+}
+
+func trust(c *cli.Context) {
+	if c.String("limit") == "" || noKey(c) {
+		fmt.Println("Limit and seed are required")
+		os.Exit(1)
+	}
+
+	trustSet := &data.TrustSet{LimitAmount: *parseAmount(c.String("limit"))}
+	trustSet.TransactionType = data.TRUST_SET
+	trustSet.Flags = new(data.TransactionFlag)
+	if c.Bool("noripple") {
+		*trustSet.Flags = *trustSet.Flags | data.TxSetNoRipple
+	}
+	if c.Bool("clearnoripple") {
+		*trustSet.Flags = *trustSet.Flags | data.TxClearNoRipple
+	}
+	if c.Bool("freeze") {
+		*trustSet.Flags = *trustSet.Flags | data.TxSetFreeze
+	}
+	if c.Bool("clearfreeze") {
+		*trustSet.Flags = *trustSet.Flags | data.TxClearFreeze
+	}
+
+	sign(c, trustSet, accountIndex(c))
+	outputTx(c, trustSet)
+}
+
+func offer(c *cli.Context) {
+	if c.String("takergets") == "" || c.String("takerpays") == "" || noKey(c) {
+		fmt.Println("TakerGets, TakerPays, and seed are required")
+		os.Exit(1)
+	}
+
+	offerCreate := &data.OfferCreate{
+		TakerGets: *parseAmount(c.String("takergets")),
+		TakerPays: *parseAmount(c.String("takerpays")),
+	}
+	offerCreate.TransactionType = data.OFFER_CREATE
+
+	if c.Int("expiration") > 0 {
+		offerCreate.Expiration = new(uint32)
+		*offerCreate.Expiration = uint32(c.Int("expiration"))
+	}
+	if c.Int("offersequence") > 0 {
+		offerCreate.OfferSequence = new(uint32)
+		*offerCreate.OfferSequence = uint32(c.Int("offersequence"))
+	}
+
+	offerCreate.Flags = new(data.TransactionFlag)
+	if c.Bool("passive") {
+		*offerCreate.Flags = *offerCreate.Flags | data.TxPassive
+	}
+	if c.Bool("ioc") {
+		*offerCreate.Flags = *offerCreate.Flags | data.TxImmediateOrCancel
+	}
+	if c.Bool("fok") {
+		*offerCreate.Flags = *offerCreate.Flags | data.TxFillOrKill
+	}
+	if c.Bool("sell") {
+		*offerCreate.Flags = *offerCreate.Flags | data.TxSell
+	}
+
+	sign(c, offerCreate, accountIndex(c))
+	outputTx(c, offerCreate)
+}
+
+func offerCancel(c *cli.Context) {
+	if c.Int("offersequence") == 0 || noKey(c) {
+		fmt.Println("OfferSequence and seed are required")
+		os.Exit(1)
+	}
+
+	offerCancel := &data.OfferCancel{OfferSequence: uint32(c.Int("offersequence"))}
+	offerCancel.TransactionType = data.OFFER_CANCEL
+
+	sign(c, offerCancel, accountIndex(c))
+	outputTx(c, offerCancel)
+}
+
+func accountSet(c *cli.Context) {
+	if c.String("set") == "" && c.String("clear") == "" && c.String("domain") == "" &&
+		c.Int("transferrate") == 0 && c.String("emailhash") == "" || noKey(c) {
+		fmt.Println("Seed and at least one of set/clear/domain/transferrate/emailhash are required")
+		os.Exit(1)
+	}
+
+	accountSet := &data.AccountSet{}
+	accountSet.TransactionType = data.ACCOUNT_SET
+
+	if c.String("set") != "" {
+		accountSet.SetFlag = new(uint32)
+		*accountSet.SetFlag = accountSetFlagValue(c.String("set"))
+	}
+	if c.String("clear") != "" {
+		accountSet.ClearFlag = new(uint32)
+		*accountSet.ClearFlag = accountSetFlagValue(c.String("clear"))
+	}
+	if c.String("domain") != "" {
+		domain := data.VariableLength(c.String("domain"))
+		accountSet.Domain = &domain
+	}
+	if c.Int("transferrate") > 0 {
+		accountSet.TransferRate = new(uint32)
+		*accountSet.TransferRate = uint32(c.Int("transferrate"))
+	}
+	if c.String("emailhash") != "" {
+		accountSet.EmailHash = parseHash128(c.String("emailhash"))
+	}
+
+	sign(c, accountSet, accountIndex(c))
+	outputTx(c, accountSet)
+}